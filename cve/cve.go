@@ -0,0 +1,41 @@
+// Package cve holds the record type shared by every scraper/feed backend, so that
+// sources as different as an HTML scrape and an NVD JSON feed can feed the same
+// output sinks.
+package cve
+
+// CPEMatch is a single affected-product range, as found in a CVE's CPE 2.3
+// applicability statements.
+type CPEMatch struct {
+	URI                   string
+	VersionStartIncluding string
+	VersionEndExcluding   string
+}
+
+// Reference is an external link cited by a CVE, tagged with its kind (Advisory,
+// Exploit, Patch, Third Party Advisory, ...).
+type Reference struct {
+	URL  string
+	Tags []string
+}
+
+// CVE is a single vulnerability record, normalized across backends.
+type CVE struct {
+	ID          string
+	Type        string
+	Description string
+	MaxCVSS     string
+	EPSSScore   string
+	Published   string
+	Updated     string
+
+	CWEIDs     []string
+	CWENames   []string
+	CPEs       []CPEMatch
+	References []Reference
+
+	// KnownExploited and EPSSPercentile are filled in by the enrich package, not by
+	// any scraper/feed backend, so they default to their zero values until a run opts
+	// into the relevant --enrich source.
+	KnownExploited bool
+	EPSSPercentile string
+}
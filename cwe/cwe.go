@@ -0,0 +1,64 @@
+// Package cwe loads a small in-memory dictionary mapping CWE IDs (e.g. "CWE-79") to
+// their human-readable name and weakness abstraction, parsed from a MITRE CWE XML
+// export (https://cwe.mitre.org/data/xml/cwec_latest.xml.zip).
+package cwe
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+)
+
+// Entry is one weakness's dictionary record.
+type Entry struct {
+	ID   string // e.g. "CWE-79"
+	Name string
+	// Category is the weakness's abstraction level (Base, Class, Variant, ...), the
+	// closest thing the MITRE XML attaches directly to a <Weakness> element.
+	Category string
+}
+
+type weaknessCatalog struct {
+	Weaknesses struct {
+		Weakness []struct {
+			ID          string `xml:"ID,attr"`
+			Name        string `xml:"Name,attr"`
+			Abstraction string `xml:"Abstraction,attr"`
+		} `xml:"Weakness"`
+	} `xml:"Weaknesses"`
+}
+
+// LoadDictionary parses a MITRE CWE XML file into an ID-keyed dictionary. A missing
+// file is not fatal: callers get an empty dictionary and fall back to raw CWE IDs.
+func LoadDictionary(path string) (map[string]Entry, error) {
+	dict := make(map[string]Entry)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return dict, nil
+		}
+		return dict, err
+	}
+
+	var catalog weaknessCatalog
+	if err := xml.Unmarshal(data, &catalog); err != nil {
+		return dict, err
+	}
+
+	for _, w := range catalog.Weaknesses.Weakness {
+		id := fmt.Sprintf("CWE-%s", w.ID)
+		dict[id] = Entry{ID: id, Name: w.Name, Category: w.Abstraction}
+	}
+
+	return dict, nil
+}
+
+// Lookup returns the human-readable name for id, falling back to id itself when the
+// dictionary has no entry (e.g. it wasn't loaded, or MITRE added the CWE since).
+func Lookup(dict map[string]Entry, id string) string {
+	if entry, ok := dict[id]; ok {
+		return entry.Name
+	}
+	return id
+}
@@ -0,0 +1,237 @@
+// Package enrich augments scraped CVE records with data that's cheaper to fetch once
+// per run than to scrape per-record: CISA's Known Exploited Vulnerabilities catalog
+// and FIRST.org's EPSS exploitation-probability scores. Both datasets are cached to
+// disk so a run that can't reach the network falls back to the last successful fetch
+// instead of disabling enrichment entirely.
+package enrich
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/iseT1enLoc/ds108/cve"
+)
+
+// Enricher augments a CVE record in place with data from one external source. Users
+// can register additional enrichers by implementing this interface and adding a case
+// to New.
+type Enricher interface {
+	// Name identifies the enricher for --enrich flag matching and log messages.
+	Name() string
+	// Load fetches (or loads from cache) whatever dataset this enricher needs before
+	// it can enrich any record. Called once per run, before any Enrich call.
+	Load() error
+	// Enrich augments record in place.
+	Enrich(record *cve.CVE)
+}
+
+// CacheDir is where enrichers persist their fetched datasets between runs.
+const CacheDir = "storage/cache"
+
+// KEVURL is where CISA publishes its Known Exploited Vulnerabilities catalog.
+const KEVURL = "https://www.cisa.gov/sites/default/files/feeds/known_exploited_vulnerabilities.json"
+
+var kevCachePath = filepath.Join(CacheDir, "kev.json")
+var kevETagPath = kevCachePath + ".etag"
+
+// KEVEnricher sets CVE.KnownExploited by cross-referencing CISA's KEV catalog.
+type KEVEnricher struct {
+	HTTPClient *http.Client
+	known      map[string]bool
+}
+
+// NewKEVEnricher returns a KEVEnricher using a default HTTP client.
+func NewKEVEnricher() *KEVEnricher {
+	return &KEVEnricher{HTTPClient: &http.Client{}}
+}
+
+func (e *KEVEnricher) Name() string { return "kev" }
+
+// kevCatalog mirrors the subset of CISA's published KEV JSON this package consumes.
+type kevCatalog struct {
+	Vulnerabilities []struct {
+		CveID string `json:"cveID"`
+	} `json:"vulnerabilities"`
+}
+
+// Load fetches the KEV catalog with a conditional request against the cached ETag,
+// falling back to the on-disk cache if the fetch fails or reports no change.
+func (e *KEVEnricher) Load() error {
+	if err := os.MkdirAll(CacheDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, KEVURL, nil)
+	if err != nil {
+		return err
+	}
+	if etag, err := os.ReadFile(kevETagPath); err == nil {
+		req.Header.Set("If-None-Match", strings.TrimSpace(string(etag)))
+	}
+
+	data, etag, fetchErr := doConditional(e.HTTPClient, req)
+	if fetchErr != nil {
+		cached, readErr := os.ReadFile(kevCachePath)
+		if readErr != nil {
+			return fmt.Errorf("fetching KEV catalog: %w (no cache to fall back to)", fetchErr)
+		}
+		data = cached
+	} else if data != nil {
+		if err := os.WriteFile(kevCachePath, data, 0644); err != nil {
+			return err
+		}
+		if etag != "" {
+			if err := os.WriteFile(kevETagPath, []byte(etag), 0644); err != nil {
+				return err
+			}
+		}
+	} else {
+		cached, readErr := os.ReadFile(kevCachePath)
+		if readErr != nil {
+			return fmt.Errorf("KEV catalog not modified, but no cache on disk: %w", readErr)
+		}
+		data = cached
+	}
+
+	var catalog kevCatalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return err
+	}
+
+	e.known = make(map[string]bool, len(catalog.Vulnerabilities))
+	for _, v := range catalog.Vulnerabilities {
+		e.known[v.CveID] = true
+	}
+	return nil
+}
+
+func (e *KEVEnricher) Enrich(record *cve.CVE) {
+	record.KnownExploited = e.known[record.ID]
+}
+
+// doConditional performs req, returning (body, etag, nil) on 200, (nil, "", nil) on
+// 304 Not Modified, or a non-nil error for anything else.
+func doConditional(client *http.Client, req *http.Request) ([]byte, string, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, "", nil
+	case http.StatusOK:
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, "", err
+		}
+		return body, resp.Header.Get("ETag"), nil
+	default:
+		return nil, "", fmt.Errorf("unexpected status %d fetching %s", resp.StatusCode, req.URL)
+	}
+}
+
+// EPSSURL is FIRST.org's daily full CSV export of EPSS scores for every scored CVE.
+const EPSSURL = "https://epss.cyentia.com/epss_scores-current.csv.gz"
+
+var epssCachePath = filepath.Join(CacheDir, "epss.csv.gz")
+
+// EPSSEnricher sets CVE.EPSSScore and CVE.EPSSPercentile from FIRST.org's daily EPSS
+// export, overriding whatever (often stale) value a scraper captured.
+type EPSSEnricher struct {
+	HTTPClient *http.Client
+	scores     map[string][2]string // CVE ID -> [score, percentile]
+}
+
+// NewEPSSEnricher returns an EPSSEnricher using a default HTTP client.
+func NewEPSSEnricher() *EPSSEnricher {
+	return &EPSSEnricher{HTTPClient: &http.Client{}}
+}
+
+func (e *EPSSEnricher) Name() string { return "epss" }
+
+// Load downloads the current EPSS export, falling back to the on-disk cache if the
+// fetch fails.
+func (e *EPSSEnricher) Load() error {
+	if err := os.MkdirAll(CacheDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	resp, fetchErr := e.HTTPClient.Get(EPSSURL)
+	var data []byte
+	if fetchErr == nil {
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			fetchErr = fmt.Errorf("unexpected status %d fetching %s", resp.StatusCode, EPSSURL)
+		} else if body, err := io.ReadAll(resp.Body); err != nil {
+			fetchErr = err
+		} else {
+			data = body
+		}
+	}
+
+	if fetchErr != nil {
+		cached, readErr := os.ReadFile(epssCachePath)
+		if readErr != nil {
+			return fmt.Errorf("fetching EPSS scores: %w (no cache to fall back to)", fetchErr)
+		}
+		data = cached
+	} else if err := os.WriteFile(epssCachePath, data, 0644); err != nil {
+		return err
+	}
+
+	return e.parse(data)
+}
+
+func (e *EPSSEnricher) parse(gzData []byte) error {
+	zr, err := gzip.NewReader(strings.NewReader(string(gzData)))
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	r := csv.NewReader(zr)
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+
+	rows, err := r.ReadAll()
+	if err != nil {
+		return err
+	}
+
+	e.scores = make(map[string][2]string, len(rows))
+	for _, row := range rows {
+		if len(row) < 3 || row[0] == "cve" {
+			continue
+		}
+		e.scores[row[0]] = [2]string{row[1], row[2]}
+	}
+	return nil
+}
+
+func (e *EPSSEnricher) Enrich(record *cve.CVE) {
+	if pair, ok := e.scores[record.ID]; ok {
+		record.EPSSScore = pair[0]
+		record.EPSSPercentile = pair[1]
+	}
+}
+
+// New builds the Enricher for a single --enrich name.
+func New(name string) (Enricher, error) {
+	switch name {
+	case "kev":
+		return NewKEVEnricher(), nil
+	case "epss":
+		return NewEPSSEnricher(), nil
+	default:
+		return nil, fmt.Errorf("unknown enricher %q (want kev or epss)", name)
+	}
+}
@@ -0,0 +1,224 @@
+// Package nvd fetches CVE records from the official NVD JSON 2.0 feeds
+// (https://nvd.nist.gov/vuln/data-feeds) as an alternative to scraping cvedetails.com.
+// Feeds are mirrored to disk as gzip files alongside a companion .meta file so repeat
+// runs can skip re-downloading a feed that hasn't changed upstream.
+package nvd
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iseT1enLoc/ds108/cve"
+)
+
+// FeedBaseURL is where the legacy mirrored .json.gz + .meta feed pairs are published.
+const FeedBaseURL = "https://nvd.nist.gov/feeds/json/cve/2.0"
+
+// MirrorDir is where downloaded feeds and their .meta files are cached between runs.
+const MirrorDir = "storage/nvd"
+
+// Fetcher downloads and parses NVD JSON 2.0 feeds, mirroring them to MirrorDir.
+type Fetcher struct {
+	HTTPClient *http.Client
+	MirrorDir  string
+}
+
+// NewFetcher returns a Fetcher that mirrors feeds under the default storage directory.
+func NewFetcher() *Fetcher {
+	return &Fetcher{
+		HTTPClient: &http.Client{Timeout: 60 * time.Second},
+		MirrorDir:  MirrorDir,
+	}
+}
+
+// feedMeta mirrors the fields NVD publishes in a feed's companion .meta file.
+type feedMeta struct {
+	LastModifiedDate string
+	SHA256           string
+}
+
+func parseMeta(body string) feedMeta {
+	var m feedMeta
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "lastModifiedDate:"):
+			m.LastModifiedDate = strings.TrimPrefix(line, "lastModifiedDate:")
+		case strings.HasPrefix(line, "sha256:"):
+			m.SHA256 = strings.TrimSpace(strings.TrimPrefix(line, "sha256:"))
+		}
+	}
+	return m
+}
+
+// FetchFeed downloads the named feed (e.g. "2024", "modified", "recent"),
+// reusing the mirrored copy when the upstream .meta file reports no change.
+func (f *Fetcher) FetchFeed(name string) ([]cve.CVE, error) {
+	if err := os.MkdirAll(f.MirrorDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	metaURL := fmt.Sprintf("%s/nvdcve-2.0-%s.meta", FeedBaseURL, name)
+	gzURL := fmt.Sprintf("%s/nvdcve-2.0-%s.json.gz", FeedBaseURL, name)
+	gzPath := filepath.Join(f.MirrorDir, fmt.Sprintf("nvdcve-2.0-%s.json.gz", name))
+	metaPath := gzPath + ".meta"
+
+	remoteMetaBody, err := f.get(metaURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", metaURL, err)
+	}
+	remoteMeta := parseMeta(string(remoteMetaBody))
+
+	if localMetaBody, err := os.ReadFile(metaPath); err == nil {
+		localMeta := parseMeta(string(localMetaBody))
+		if localMeta.SHA256 != "" && localMeta.SHA256 == remoteMeta.SHA256 {
+			if data, err := os.ReadFile(gzPath); err == nil {
+				return parseFeed(data)
+			}
+		}
+	}
+
+	gzData, err := f.get(gzURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", gzURL, err)
+	}
+
+	sum := sha256.Sum256(gzData)
+	if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, remoteMeta.SHA256) {
+		return nil, fmt.Errorf("sha256 mismatch for %s: got %s, meta says %s", gzURL, got, remoteMeta.SHA256)
+	}
+
+	if err := os.WriteFile(gzPath, gzData, 0644); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(metaPath, remoteMetaBody, 0644); err != nil {
+		return nil, err
+	}
+
+	return parseFeed(gzData)
+}
+
+// FetchYear downloads the yearly feed for the given year (e.g. "2024").
+func (f *Fetcher) FetchYear(year string) ([]cve.CVE, error) {
+	return f.FetchFeed(year)
+}
+
+// FetchModified downloads the rolling feed of CVEs modified in the last 8 days.
+func (f *Fetcher) FetchModified() ([]cve.CVE, error) {
+	return f.FetchFeed("modified")
+}
+
+// FetchRecent downloads the rolling feed of CVEs added in the last 8 days.
+func (f *Fetcher) FetchRecent() ([]cve.CVE, error) {
+	return f.FetchFeed("recent")
+}
+
+func (f *Fetcher) get(url string) ([]byte, error) {
+	resp, err := f.HTTPClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d for %s", resp.StatusCode, url)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// nvdFeed mirrors the subset of the NVD JSON 2.0 schema this package consumes.
+type nvdFeed struct {
+	Vulnerabilities []struct {
+		CVE struct {
+			ID           string `json:"id"`
+			Published    string `json:"published"`
+			LastModified string `json:"lastModified"`
+			Descriptions []struct {
+				Lang  string `json:"lang"`
+				Value string `json:"value"`
+			} `json:"descriptions"`
+			Metrics struct {
+				CvssMetricV40 []struct {
+					CvssData struct {
+						BaseScore float64 `json:"baseScore"`
+					} `json:"cvssData"`
+				} `json:"cvssMetricV40"`
+				CvssMetricV31 []struct {
+					CvssData struct {
+						BaseScore float64 `json:"baseScore"`
+					} `json:"cvssData"`
+				} `json:"cvssMetricV31"`
+				CvssMetricV30 []struct {
+					CvssData struct {
+						BaseScore float64 `json:"baseScore"`
+					} `json:"cvssData"`
+				} `json:"cvssMetricV30"`
+				CvssMetricV2 []struct {
+					CvssData struct {
+						BaseScore float64 `json:"baseScore"`
+					} `json:"cvssData"`
+				} `json:"cvssMetricV2"`
+			} `json:"metrics"`
+		} `json:"cve"`
+	} `json:"vulnerabilities"`
+}
+
+func parseFeed(gzData []byte) ([]cve.CVE, error) {
+	zr, err := gzip.NewReader(strings.NewReader(string(gzData)))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	var feed nvdFeed
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return nil, err
+	}
+
+	cves := make([]cve.CVE, 0, len(feed.Vulnerabilities))
+	for _, v := range feed.Vulnerabilities {
+		description := ""
+		for _, d := range v.CVE.Descriptions {
+			if d.Lang == "en" {
+				description = d.Value
+				break
+			}
+		}
+
+		maxCVSS := ""
+		switch {
+		case len(v.CVE.Metrics.CvssMetricV40) > 0:
+			maxCVSS = strconv.FormatFloat(v.CVE.Metrics.CvssMetricV40[0].CvssData.BaseScore, 'f', 1, 64)
+		case len(v.CVE.Metrics.CvssMetricV31) > 0:
+			maxCVSS = strconv.FormatFloat(v.CVE.Metrics.CvssMetricV31[0].CvssData.BaseScore, 'f', 1, 64)
+		case len(v.CVE.Metrics.CvssMetricV30) > 0:
+			maxCVSS = strconv.FormatFloat(v.CVE.Metrics.CvssMetricV30[0].CvssData.BaseScore, 'f', 1, 64)
+		case len(v.CVE.Metrics.CvssMetricV2) > 0:
+			maxCVSS = strconv.FormatFloat(v.CVE.Metrics.CvssMetricV2[0].CvssData.BaseScore, 'f', 1, 64)
+		}
+
+		cves = append(cves, cve.CVE{
+			ID:          v.CVE.ID,
+			Type:        "N/A",
+			Description: description,
+			MaxCVSS:     maxCVSS,
+			Published:   v.CVE.Published,
+			Updated:     v.CVE.LastModified,
+		})
+	}
+	return cves, nil
+}
@@ -1,30 +1,300 @@
 package main
 
 import (
-	"encoding/csv"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"math/rand"
 	"os"
-	"path/filepath"
+	"os/signal"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gocolly/colly"
+
+	"github.com/iseT1enLoc/ds108/cve"
+	"github.com/iseT1enLoc/ds108/cwe"
+	"github.com/iseT1enLoc/ds108/enrich"
+	"github.com/iseT1enLoc/ds108/feeds/nvd"
+	"github.com/iseT1enLoc/ds108/scheduler"
+	"github.com/iseT1enLoc/ds108/sinks"
+)
+
+// jobStorePath persists the scheduler's pending (year, month, page) jobs so a run
+// interrupted with Ctrl-C can resume instead of starting over.
+const jobStorePath = "storage/scheduler/jobs.db"
+
+var (
+	rateFlag             = flag.Float64("rate", 2, "max requests per second across all workers")
+	politenessDelayFlag  = flag.Duration("politeness-delay", time.Second, "minimum delay between requests to the same host")
+	circuitThresholdFlag = flag.Int("circuit-breaker-threshold", 5, "consecutive 429/403 responses before the circuit breaker opens")
+	circuitCooldownFlag  = flag.Duration("circuit-breaker-cooldown", 2*time.Minute, "how long the circuit breaker stays open once tripped")
+	dryRunFlag           = flag.Bool("dry-run", false, "print the cvedetails job plan without fetching anything (ignored with --source=nvd)")
+	enrichFlag           = flag.String("enrich", "", "comma-separated enrichers to run before output (kev, epss)")
+	sortFlag             = flag.Bool("sort", false, "sort output by (KnownExploited desc, EPSSPercentile desc, MaxCVSS desc) instead of streaming in scrape order")
 )
 
-type CVE struct {
-	ID          string
-	Type        string
-	Description string
-	MaxCVSS     string
-	EPSSScore   string
-	Published   string
-	Updated     string
+// cweDictPath is the seed MITRE CWE XML export used to resolve CWE IDs to names.
+const cweDictPath = "cwe/cwe_dictionary.xml"
+
+// cweDict maps "CWE-NNN" to its human-readable name and abstraction. It's loaded once
+// in main and read-only afterwards, so it's safe to share across worker goroutines.
+var cweDict map[string]cwe.Entry
+
+// outputList collects repeated --output flags into an ordered slice.
+type outputList []string
+
+func (o *outputList) String() string { return strings.Join(*o, ",") }
+func (o *outputList) Set(value string) error {
+	*o = append(*o, value)
+	return nil
 }
 
 const BaseURL = "https://www.cvedetails.com"
+const cveDetailsHost = "www.cvedetails.com"
+
+// updatedDateLayout matches the "updateDate"/"publishDate" text cvedetails.com renders.
+const updatedDateLayout = "2006-01-02"
+
+// stateFile persists the high-water mark used to derive --since on the next run.
+const stateFile = "storage/state.json"
+
+// ScraperState is the on-disk record of how far a previous run got.
+type ScraperState struct {
+	// LastUpdated is the most recent per-CVE `Updated` timestamp observed across all
+	// processed records, in RFC3339. The next run defaults --since to this value.
+	LastUpdated string `json:"last_updated"`
+}
+
+var sinceFlag = flag.String("since", "", "only fetch CVEs updated on/after this RFC3339 timestamp (default: derived from storage/state.json)")
+var sourceFlag = flag.String("source", "cvedetails", "data source to fetch from: cvedetails or nvd")
+
+var outputFlag outputList
+
+func init() {
+	flag.Var(&outputFlag, "output", "output sink to write to (csv, ndjson, sqlite, osv); repeatable, defaults to csv")
+}
+
+// parseNVDUpdated parses the ISO-8601 timestamps used by the NVD JSON 2.0 feeds, which
+// don't share cvedetails.com's plain date format.
+func parseNVDUpdated(s string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02T15:04:05.000", time.RFC3339, "2006-01-02T15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp: %q", s)
+}
+
+// runNVD fetches CVE records from the NVD JSON feeds instead of scraping cvedetails.com.
+// A zero since pulls every yearly feed; otherwise only the rolling modified/recent
+// feeds are fetched and filtered client-side against the cutoff.
+func runNVD(since time.Time, dispatcher *sinks.Dispatcher, enrichers []enrich.Enricher) {
+	fetcher := nvd.NewFetcher()
+	var records []cve.CVE
+
+	if since.IsZero() {
+		for _, year := range years {
+			recs, err := fetcher.FetchYear(year)
+			if err != nil {
+				log.Printf("Error fetching NVD feed for %s: %v", year, err)
+				continue
+			}
+			records = append(records, recs...)
+		}
+	} else {
+		modified, err := fetcher.FetchModified()
+		if err != nil {
+			log.Printf("Error fetching NVD modified feed: %v", err)
+		}
+		recent, err := fetcher.FetchRecent()
+		if err != nil {
+			log.Printf("Error fetching NVD recent feed: %v", err)
+		}
+		records = append(records, modified...)
+		records = append(records, recent...)
+	}
+
+	for _, record := range records {
+		updated, err := parseNVDUpdated(record.Updated)
+		if err != nil {
+			log.Printf("Skipping %s, unparseable Updated %q: %v", record.ID, record.Updated, err)
+			continue
+		}
+		if !since.IsZero() && updated.Before(since) {
+			continue
+		}
+		if recordDelta(record, updated) {
+			dispatchRecord(enrichRecord(record, enrichers), dispatcher)
+		}
+	}
+}
+
+// loadState reads the persisted state, returning a zero-value state if none exists yet.
+func loadState() (ScraperState, error) {
+	var st ScraperState
+	data, err := os.ReadFile(stateFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return st, nil
+		}
+		return st, err
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return st, err
+	}
+	return st, nil
+}
+
+// saveState persists the state, creating the storage directory if needed.
+func saveState(st ScraperState) error {
+	if err := os.MkdirAll("storage", os.ModePerm); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(stateFile, data, 0644)
+}
+
+// resolveSince determines the cutoff for incremental fetching: the --since flag takes
+// precedence, falling back to the state file, falling back to a full scrape.
+func resolveSince() time.Time {
+	if *sinceFlag != "" {
+		t, err := time.Parse(time.RFC3339, *sinceFlag)
+		if err != nil {
+			log.Fatalf("Invalid --since value %q: %v", *sinceFlag, err)
+		}
+		return t
+	}
+
+	st, err := loadState()
+	if err != nil {
+		log.Printf("Error reading %s, falling back to a full scrape: %v", stateFile, err)
+		return time.Time{}
+	}
+	if st.LastUpdated == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, st.LastUpdated)
+	if err != nil {
+		log.Printf("Error parsing stored last_updated %q, falling back to a full scrape: %v", st.LastUpdated, err)
+		return time.Time{}
+	}
+	return t
+}
+
+// parseUpdated parses a scraped "Updated" field into a comparable time.Time.
+func parseUpdated(s string) (time.Time, error) {
+	return time.Parse(updatedDateLayout, strings.TrimSpace(s))
+}
+
+// deltaMu guards deltaRecords and seenCVEs, which are shared across worker goroutines
+// for the lifetime of a single run.
+var deltaMu sync.Mutex
+var deltaRecords []cve.CVE
+var seenCVEs = make(map[string]bool)
+var maxUpdatedSeen time.Time
+
+// recordDelta deduplicates a CVE by ID and tracks the newest Updated timestamp seen,
+// so a CVE added and modified within the same window only appears once. It reports
+// whether this is the first time record.ID has been seen this run.
+func recordDelta(record cve.CVE, updated time.Time) bool {
+	deltaMu.Lock()
+	defer deltaMu.Unlock()
+
+	isNew := !seenCVEs[record.ID]
+	if isNew {
+		seenCVEs[record.ID] = true
+		deltaRecords = append(deltaRecords, record)
+	}
+	if updated.After(maxUpdatedSeen) {
+		maxUpdatedSeen = updated
+	}
+	return isNew
+}
+
+// loadEnrichers builds and loads the Enricher set named by --enrich. A source that
+// fails to load is logged and skipped rather than aborting the run, since enrichment
+// is a bonus on top of the scraped record, not something the rest of the run depends on.
+func loadEnrichers(names string) []enrich.Enricher {
+	var active []enrich.Enricher
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		e, err := enrich.New(name)
+		if err != nil {
+			log.Printf("Error setting up %q enricher: %v", name, err)
+			continue
+		}
+		if err := e.Load(); err != nil {
+			log.Printf("Error loading %q enricher, proceeding without it: %v", name, err)
+			continue
+		}
+		active = append(active, e)
+	}
+	return active
+}
+
+// enrichRecord applies every active enricher to record in turn.
+func enrichRecord(record cve.CVE, enrichers []enrich.Enricher) cve.CVE {
+	for _, e := range enrichers {
+		e.Enrich(&record)
+	}
+	return record
+}
+
+// pendingMu guards pendingRecords, the buffer --sort uses to hold every record until
+// the run finishes so it can write them out in sorted order instead of scrape order.
+var pendingMu sync.Mutex
+var pendingRecords []cve.CVE
+
+// dispatchRecord sends record straight to dispatcher, unless --sort is set, in which
+// case it's buffered in pendingRecords for a single sorted pass once the run finishes.
+func dispatchRecord(record cve.CVE, dispatcher *sinks.Dispatcher) {
+	if *sortFlag {
+		pendingMu.Lock()
+		pendingRecords = append(pendingRecords, record)
+		pendingMu.Unlock()
+		return
+	}
+	dispatcher.Send(record)
+}
+
+// parseFloatScore parses a CVSS score or EPSS percentile for sorting, treating an
+// empty or malformed value as the lowest possible rank rather than failing the sort.
+func parseFloatScore(s string) float64 {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return -1
+	}
+	return v
+}
+
+// sortRecords orders records by (KnownExploited desc, EPSSPercentile desc, MaxCVSS desc),
+// the risk-prioritized order --sort asks for.
+func sortRecords(records []cve.CVE) {
+	sort.SliceStable(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if a.KnownExploited != b.KnownExploited {
+			return a.KnownExploited
+		}
+		if pa, pb := parseFloatScore(a.EPSSPercentile), parseFloatScore(b.EPSSPercentile); pa != pb {
+			return pa > pb
+		}
+		return parseFloatScore(a.MaxCVSS) > parseFloatScore(b.MaxCVSS)
+	})
+}
 
 var monthMapping = map[string]string{
 	"January": "01", "February": "02", "March": "03", "April": "04", "May": "05", "June": "06",
@@ -53,6 +323,12 @@ const (
 	maxRetries       = 3
 	concurrencyLimit = 2
 	logFile          = "scrape.log"
+
+	// maxJobRequeues bounds how many times runWorker resubmits a page job after
+	// scrapePage exhausts its own per-request retries, so a page that's permanently
+	// broken (not just transiently erroring) eventually gets marked done instead of
+	// looping forever.
+	maxJobRequeues = 5
 )
 
 // Initialize logger
@@ -75,8 +351,17 @@ func randomSleep() {
 	time.Sleep(delay)
 }
 
-// Get CVE type from details page
-func getCVEType(url string) string {
+// cveDetails is everything scraped from a single CVE's detail page.
+type cveDetails struct {
+	Type       string
+	CWEIDs     []string
+	CWENames   []string
+	CPEs       []cve.CPEMatch
+	References []cve.Reference
+}
+
+// Get CVE type, CWE IDs, affected CPE ranges, and references from the detail page.
+func getCVEDetails(url string, gate *scheduler.FetchGate) cveDetails {
 	c := colly.NewCollector()
 
 	// Set random User-Agent
@@ -87,27 +372,69 @@ func getCVEType(url string) string {
 		c.SetProxy(proxyList[rand.Intn(len(proxyList))])
 	}
 
-	var cveType string = "N/A"
+	details := cveDetails{Type: "N/A"}
+
+	statusCode := 0
+	c.OnResponse(func(r *colly.Response) { statusCode = r.StatusCode })
+	c.OnError(func(r *colly.Response, _ error) { statusCode = r.StatusCode })
 
 	c.OnHTML("#cve_catslabelsnotes_div span.ssc-vuln-cat", func(e *colly.HTMLElement) {
-		cveType = strings.TrimSpace(e.Text)
+		details.Type = strings.TrimSpace(e.Text)
+	})
+
+	c.OnHTML("div[data-tsvfield='cweIds'] a", func(e *colly.HTMLElement) {
+		id := strings.TrimSpace(e.Text)
+		details.CWEIDs = append(details.CWEIDs, id)
+		details.CWENames = append(details.CWENames, cwe.Lookup(cweDict, id))
+	})
+
+	c.OnHTML("tr[data-cpe23uri]", func(e *colly.HTMLElement) {
+		details.CPEs = append(details.CPEs, cve.CPEMatch{
+			URI:                   e.Attr("data-cpe23uri"),
+			VersionStartIncluding: e.Attr("data-version-start-including"),
+			VersionEndExcluding:   e.Attr("data-version-end-excluding"),
+		})
+	})
+
+	c.OnHTML("div#references a", func(e *colly.HTMLElement) {
+		var tags []string
+		if tag := e.Attr("data-ref-tag"); tag != "" {
+			tags = strings.Split(tag, ",")
+		}
+		details.References = append(details.References, cve.Reference{
+			URL:  e.Attr("href"),
+			Tags: tags,
+		})
 	})
 
+	host := cveDetailsHost
 	for i := 0; i < maxRetries; i++ {
+		if err := gate.Wait(host); err != nil {
+			log.Printf("Skipping CVE detail fetch for %s: %v", url, err)
+			return details
+		}
 		err := c.Visit(url)
+		gate.RecordStatus(statusCode)
 		if err == nil {
-			return cveType
+			return details
 		}
-		log.Printf("Retrying CVE type fetch: %d/%d after error: %v", i+1, maxRetries, err)
-		time.Sleep(5 * time.Second) // Backoff before retry
+		backoff := scheduler.Backoff(i, time.Second, 60*time.Second)
+		log.Printf("Retrying CVE detail fetch: %d/%d after %v (error: %v)", i+1, maxRetries, backoff, err)
+		time.Sleep(backoff)
 	}
 
-	return cveType
+	return details
 }
 
-// Scrape single page
-func scrapePage(year, monthText, monthNum string, page int) ([]CVE, error) {
-	url := fmt.Sprintf("%s/vulnerability-list/year-%s/month-%s/%s.html?page=%d&order=1", BaseURL, year, monthNum, monthText, page)
+// Scrape single page. When since is non-zero, entries order by update date (newest
+// first) and the caller should stop paginating once stop is true: every entry on the
+// page is older than the cutoff, so no later page can contain anything newer.
+func scrapePage(year, monthText, monthNum string, page int, since time.Time, gate *scheduler.FetchGate) (cves []cve.CVE, stop bool, err error) {
+	order := "1"
+	if !since.IsZero() {
+		order = "3" // sort by update date, descending
+	}
+	url := fmt.Sprintf("%s/vulnerability-list/year-%s/month-%s/%s.html?page=%d&order=%s", BaseURL, year, monthNum, monthText, page, order)
 	fmt.Println("Fetching:", url)
 
 	// Log the fetched URL
@@ -116,6 +443,9 @@ func scrapePage(year, monthText, monthNum string, page int) ([]CVE, error) {
 	randomSleep() // Add human-like delay
 
 	c := colly.NewCollector()
+	statusCode := 0
+	c.OnResponse(func(r *colly.Response) { statusCode = r.StatusCode })
+	c.OnError(func(r *colly.Response, _ error) { statusCode = r.StatusCode })
 
 	// Set random User-Agent
 	c.UserAgent = userAgents[rand.Intn(len(userAgents))]
@@ -125,7 +455,7 @@ func scrapePage(year, monthText, monthNum string, page int) ([]CVE, error) {
 		c.SetProxy(proxyList[rand.Intn(len(proxyList))])
 	}
 
-	var cves []CVE
+	allOlder := true
 
 	c.OnHTML("div[data-tsvfield='cveinfo']", func(e *colly.HTMLElement) {
 		cveID := e.ChildText("h3[data-tsvfield='cveId']")
@@ -136,103 +466,220 @@ func scrapePage(year, monthText, monthNum string, page int) ([]CVE, error) {
 		published := e.ChildText("div[data-tsvfield='publishDate']")
 		updated := e.ChildText("div[data-tsvfield='updateDate']")
 
-		// Get CVE type from details page
-		cveType := getCVEType(cveLink)
+		updatedTime, parseErr := parseUpdated(updated)
+		if parseErr == nil && !updatedTime.Before(since) {
+			allOlder = false
+		} else if !since.IsZero() {
+			return // older than the cutoff: skip, don't hit the detail page for it
+		}
+
+		// Get CWE/CPE/reference enrichment from the detail page
+		details := getCVEDetails(cveLink, gate)
 
-		cves = append(cves, CVE{
+		cves = append(cves, cve.CVE{
 			ID:          cveID,
-			Type:        cveType,
+			Type:        details.Type,
 			Description: description,
 			MaxCVSS:     maxCVSS,
 			EPSSScore:   epssScore,
 			Published:   published,
 			Updated:     updated,
+			CWEIDs:      details.CWEIDs,
+			CWENames:    details.CWENames,
+			CPEs:        details.CPEs,
+			References:  details.References,
 		})
 	})
 
 	for i := 0; i < maxRetries; i++ {
-		err := c.Visit(url)
+		if waitErr := gate.Wait(cveDetailsHost); waitErr != nil {
+			return nil, false, waitErr
+		}
+		err = c.Visit(url)
+		gate.RecordStatus(statusCode)
 		if err == nil {
 			break
 		}
-		log.Printf("Retry %d/%d for %s: %v", i+1, maxRetries, url, err)
-		time.Sleep(5 * time.Second) // Backoff
+		backoff := scheduler.Backoff(i, time.Second, 60*time.Second)
+		log.Printf("Retry %d/%d for %s after %v: %v", i+1, maxRetries, url, backoff, err)
+		time.Sleep(backoff)
 	}
 
-	return cves, nil
+	return cves, !since.IsZero() && allOlder, err
 }
 
-// Save data to CSV
-func saveToCSV(year, month string, records []CVE) {
-	if len(records) == 0 {
-		return
-	}
+// runWorker pulls jobs off queue, rate-limited and circuit-broken through gate, until
+// the queue drains or ctx is cancelled (Ctrl-C). On cancellation it finishes whatever
+// job it's mid-fetch on and leaves the rest pending in the job store for the next run.
+func runWorker(ctx context.Context, queue *scheduler.Queue, gate *scheduler.FetchGate, since time.Time, dispatcher *sinks.Dispatcher, enrichers []enrich.Enricher, wg *sync.WaitGroup) {
+	defer wg.Done()
 
-	outputDir := filepath.Join("storage", year)
-	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
-		log.Printf("Error creating output directory: %v", err)
-		return
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job, ok := <-queue.Jobs():
+			if !ok {
+				return
+			}
+
+			records, stop, err := scrapePage(job.Year, job.Month, monthMapping[job.Month], job.Page, since, gate)
+			if err != nil {
+				if errors.Is(err, scheduler.ErrCircuitOpen) {
+					cooldown := gate.CircuitCooldown()
+					log.Printf("Circuit breaker open, requeueing %s and backing off %v", job, cooldown)
+					queue.Requeue(job)
+					time.Sleep(cooldown)
+					continue
+				}
+
+				if job.Attempt+1 >= maxJobRequeues {
+					log.Printf("Giving up on %s after %d attempts: %v", job, job.Attempt+1, err)
+					if doneErr := queue.Done(job, nil); doneErr != nil {
+						log.Printf("Error marking %s done: %v", job, doneErr)
+					}
+					continue
+				}
+
+				next := job
+				next.Attempt++
+				log.Printf("Error scraping %s (attempt %d/%d), requeueing: %v", job, next.Attempt, maxJobRequeues, err)
+				queue.Requeue(next)
+				continue
+			}
+
+			for _, record := range records {
+				updated, parseErr := parseUpdated(record.Updated)
+				if parseErr != nil {
+					log.Printf("Unparseable Updated %q for %s, deduping without advancing the high-water mark: %v", record.Updated, record.ID, parseErr)
+				}
+				if recordDelta(record, updated) {
+					dispatchRecord(enrichRecord(record, enrichers), dispatcher)
+				}
+			}
+
+			var next *scheduler.Job
+			if len(records) > 0 && !stop {
+				next = &scheduler.Job{Year: job.Year, Month: job.Month, Page: job.Page + 1}
+			}
+			if doneErr := queue.Done(job, next); doneErr != nil {
+				log.Printf("Error marking %s done: %v", job, doneErr)
+			}
+		}
 	}
+}
 
-	filename := filepath.Join(outputDir, fmt.Sprintf("CVE_%s_%s.csv", year, month))
-	file, err := os.Create(filename)
+func main() {
+	flag.Parse()
+	startTime := time.Now()
+
+	var err error
+	cweDict, err = cwe.LoadDictionary(cweDictPath)
 	if err != nil {
-		log.Printf("Error creating CSV file: %v", err)
-		return
+		log.Printf("Error loading CWE dictionary from %s, CWE names will fall back to raw IDs: %v", cweDictPath, err)
 	}
-	defer file.Close()
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-
-	writer.Write([]string{"CVE ID", "CVE Type", "Description", "Max CVSS", "EPSS Score", "Published", "Updated"})
-
-	for _, record := range records {
-		writer.Write([]string{record.ID, record.Type, record.Description, record.MaxCVSS, record.EPSSScore, record.Published, record.Updated})
+	since := resolveSince()
+	if since.IsZero() {
+		fmt.Println("Running a full scrape (no --since and no prior state)")
+	} else {
+		fmt.Println("Running incrementally since:", since.Format(time.RFC3339))
 	}
 
-	fmt.Println("✅ Saved to:", filename)
-	log.Printf("✅ Saved to: %s", filename)
-}
+	if len(outputFlag) == 0 {
+		outputFlag = outputList{"csv"}
+	}
+	runTimestamp := startTime.Format("20060102T150405Z0700")
+	var activeSinks []sinks.OutputSink
+	for _, name := range outputFlag {
+		sink, err := sinks.New(name, runTimestamp)
+		if err != nil {
+			log.Fatalf("Error setting up output sink: %v", err)
+		}
+		activeSinks = append(activeSinks, sink)
+	}
+	dispatcher := sinks.NewDispatcher(activeSinks)
 
-// Worker for concurrency
-func worker(year, month string, semaphore chan struct{}, wg *sync.WaitGroup) {
-	defer wg.Done()
+	activeEnrichers := loadEnrichers(*enrichFlag)
 
-	monthNum := monthMapping[month]
-	page := 1
-	var allRecords []CVE
+	if *sourceFlag == "nvd" {
+		runNVD(since, dispatcher, activeEnrichers)
+	} else {
+		store, err := scheduler.OpenStore(jobStorePath)
+		if err != nil {
+			log.Fatalf("Error opening job store: %v", err)
+		}
+		defer store.Close()
 
-	for {
-		semaphore <- struct{}{}
+		pending, err := store.Pending()
+		if err != nil {
+			log.Fatalf("Error reading pending jobs: %v", err)
+		}
 
-		records, err := scrapePage(year, month, monthNum, page)
-		if err != nil || len(records) == 0 {
-			<-semaphore
-			break
+		var plan []scheduler.Job
+		if len(pending) > 0 {
+			fmt.Printf("Resuming %d pending job(s) from an interrupted run\n", len(pending))
+			plan = pending
+		} else {
+			for _, year := range years {
+				for _, month := range months {
+					plan = append(plan, scheduler.Job{Year: year, Month: month, Page: 1})
+				}
+			}
 		}
 
-		allRecords = append(allRecords, records...)
-		page++
-		<-semaphore
+		if *dryRunFlag {
+			for _, job := range plan {
+				fmt.Println("would fetch:", job)
+			}
+		} else {
+			queue, err := scheduler.NewQueue(store)
+			if err != nil {
+				log.Fatalf("Error loading job queue: %v", err)
+			}
+			if len(pending) == 0 {
+				for _, job := range plan {
+					if err := queue.Seed(job); err != nil {
+						log.Fatalf("Error seeding job %s: %v", job, err)
+					}
+				}
+			}
+			queue.Start()
+
+			gate := scheduler.NewFetchGate(*rateFlag, *politenessDelayFlag, *circuitThresholdFlag, *circuitCooldownFlag)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			sigCh := make(chan os.Signal, 1)
+			signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+			go func() {
+				<-sigCh
+				fmt.Println("\nReceived interrupt, finishing in-flight jobs; remaining jobs stay queued for the next run")
+				cancel()
+			}()
+
+			var workerWG sync.WaitGroup
+			for i := 0; i < concurrencyLimit; i++ {
+				workerWG.Add(1)
+				go runWorker(ctx, queue, gate, since, dispatcher, activeEnrichers, &workerWG)
+			}
+			workerWG.Wait()
+			signal.Stop(sigCh)
+		}
 	}
 
-	saveToCSV(year, month, allRecords)
-}
-
-func main() {
-	startTime := time.Now()
-
-	var wg sync.WaitGroup
-	semaphore := make(chan struct{}, concurrencyLimit)
+	if *sortFlag {
+		sortRecords(pendingRecords)
+		for _, record := range pendingRecords {
+			dispatcher.Send(record)
+		}
+	}
+	dispatcher.Close()
 
-	for _, year := range years {
-		for _, month := range months {
-			wg.Add(1)
-			go worker(year, month, semaphore, &wg)
+	if !maxUpdatedSeen.IsZero() {
+		if err := saveState(ScraperState{LastUpdated: maxUpdatedSeen.Format(time.RFC3339)}); err != nil {
+			log.Printf("Error saving state: %v", err)
 		}
 	}
 
-	wg.Wait()
 	fmt.Println("✅ Completed all downloads in:", time.Since(startTime))
 }
@@ -0,0 +1,350 @@
+// Package scheduler replaces the ad-hoc sync.WaitGroup + semaphore fan-out with a
+// persistent, rate-limited job queue: pending (year, month, page) jobs survive a
+// Ctrl-C, a global token bucket and per-host politeness delay keep requests polite,
+// and a circuit breaker backs off once the site starts returning 429/403.
+package scheduler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrCircuitOpen is returned by FetchGate.Wait while the circuit breaker is tripped.
+// Callers should requeue the job rather than treating this as a failed fetch attempt.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// Job is one page of one month's vulnerability listing to fetch. Attempt counts
+// resubmissions after a failed fetch (not persisted to the Store, which keys only on
+// Year/Month/Page) so a worker can give up after a bounded number of retries instead
+// of retrying a broken page forever.
+type Job struct {
+	Year    string
+	Month   string
+	Page    int
+	Attempt int
+}
+
+func (j Job) String() string {
+	return fmt.Sprintf("%s/%s page %d", j.Year, j.Month, j.Page)
+}
+
+// Store persists pending jobs to a SQLite file so a run interrupted with Ctrl-C can
+// resume from the last committed job instead of starting over.
+type Store struct {
+	db *sql.DB
+}
+
+// OpenStore opens (or creates) the job store at path.
+func OpenStore(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	const createTable = `
+CREATE TABLE IF NOT EXISTS pending_jobs (
+	year  TEXT NOT NULL,
+	month TEXT NOT NULL,
+	page  INTEGER NOT NULL,
+	PRIMARY KEY (year, month, page)
+)`
+	if _, err := db.Exec(createTable); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error { return s.db.Close() }
+
+// Add records job as pending. Re-adding an already-pending job is a no-op.
+func (s *Store) Add(job Job) error {
+	_, err := s.db.Exec(`INSERT OR IGNORE INTO pending_jobs (year, month, page) VALUES (?, ?, ?)`, job.Year, job.Month, job.Page)
+	return err
+}
+
+// Remove marks job as done.
+func (s *Store) Remove(job Job) error {
+	_, err := s.db.Exec(`DELETE FROM pending_jobs WHERE year = ? AND month = ? AND page = ?`, job.Year, job.Month, job.Page)
+	return err
+}
+
+// Pending returns every job left over from a prior, interrupted run.
+func (s *Store) Pending() ([]Job, error) {
+	rows, err := s.db.Query(`SELECT year, month, page FROM pending_jobs ORDER BY year, month, page`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		var j Job
+		if err := rows.Scan(&j.Year, &j.Month, &j.Page); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// Queue is an in-memory work channel backed by Store, so jobs discovered while
+// running (the next page of a month) are both queued for a worker and persisted for
+// resumption, while completed jobs are cleared from disk.
+type Queue struct {
+	store *Store
+	ch    chan Job
+	wg    sync.WaitGroup
+}
+
+// NewQueue loads any jobs left pending from a previous run onto the channel. The
+// caller must call Start once it's done with any additional Seed calls: the queue
+// doesn't watch for drain-to-zero until then, so a fresh run (no pending jobs, wg at
+// 0) can't have its channel closed out from under a Seed that hasn't happened yet.
+func NewQueue(store *Store) (*Queue, error) {
+	pending, err := store.Pending()
+	if err != nil {
+		return nil, err
+	}
+	q := &Queue{store: store, ch: make(chan Job, 1024)}
+	for _, j := range pending {
+		q.wg.Add(1)
+		q.ch <- j
+	}
+
+	return q, nil
+}
+
+// Start begins watching for the queue draining to zero in-flight jobs, closing the
+// channel at that point. Call it exactly once, after any initial Seed calls, so the
+// channel can't close before those jobs land on it.
+func (q *Queue) Start() {
+	go func() {
+		q.wg.Wait()
+		close(q.ch)
+	}()
+}
+
+// Seed adds a brand new job to both the store and the channel.
+func (q *Queue) Seed(job Job) error {
+	if err := q.store.Add(job); err != nil {
+		return err
+	}
+	q.wg.Add(1)
+	q.ch <- job
+	return nil
+}
+
+// Jobs is the channel workers range over.
+func (q *Queue) Jobs() <-chan Job {
+	return q.ch
+}
+
+// Requeue puts a job that failed transiently back on the channel without touching
+// the store (it's already recorded as pending there) or the in-flight count: the
+// token for job was never discharged by Done, so it's reused rather than re-added.
+func (q *Queue) Requeue(job Job) {
+	q.ch <- job
+}
+
+// Done marks job as finished. If next is non-nil, it's queued as the job's
+// replacement (e.g. the next page of the same month) so the in-flight count never
+// drops to zero while there's still more work to discover.
+func (q *Queue) Done(job Job, next *Job) error {
+	if next != nil {
+		if err := q.store.Add(*next); err != nil {
+			return err
+		}
+		q.wg.Add(1)
+		q.ch <- *next
+	}
+	if err := q.store.Remove(job); err != nil {
+		return err
+	}
+	q.wg.Done()
+	return nil
+}
+
+// Backoff computes an exponential delay with jitter for retry attempt (0-indexed),
+// capped at max.
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	d := base * time.Duration(math.Pow(2, float64(attempt)))
+	if d <= 0 || d > max {
+		d = max
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d)/2+1))
+}
+
+// RateLimiter is a simple token bucket shared across every worker.
+type RateLimiter struct {
+	mu     sync.Mutex
+	tokens float64
+	max    float64
+	rate   float64 // tokens added per second
+	last   time.Time
+}
+
+// NewRateLimiter creates a limiter allowing requestsPerSecond sustained throughput.
+func NewRateLimiter(requestsPerSecond float64) *RateLimiter {
+	return &RateLimiter{tokens: requestsPerSecond, max: requestsPerSecond, rate: requestsPerSecond, last: time.Now()}
+}
+
+// Wait blocks until a token is available.
+func (r *RateLimiter) Wait() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.tokens = math.Min(r.max, r.tokens+now.Sub(r.last).Seconds()*r.rate)
+	r.last = now
+	r.tokens--
+
+	// A negative balance is a token reserved ahead of time: sleep until it has accrued,
+	// so concurrent callers queue up behind each other instead of waking together.
+	if r.tokens < 0 {
+		wait := time.Duration(-r.tokens / r.rate * float64(time.Second))
+		r.mu.Unlock()
+		time.Sleep(wait)
+		r.mu.Lock()
+	}
+}
+
+// PolitenessTracker enforces a minimum delay between requests to the same host.
+type PolitenessTracker struct {
+	mu    sync.Mutex
+	last  map[string]time.Time
+	delay time.Duration
+}
+
+// NewPolitenessTracker creates a tracker enforcing delay between requests per host.
+func NewPolitenessTracker(delay time.Duration) *PolitenessTracker {
+	return &PolitenessTracker{last: make(map[string]time.Time), delay: delay}
+}
+
+// Wait blocks, if needed, so the next request to host respects the politeness delay.
+func (p *PolitenessTracker) Wait(host string) {
+	p.mu.Lock()
+	now := time.Now()
+	next := now
+	if last, seen := p.last[host]; seen && last.Add(p.delay).After(now) {
+		next = last.Add(p.delay)
+	}
+	p.last[host] = next
+	p.mu.Unlock()
+
+	time.Sleep(next.Sub(now))
+}
+
+// CircuitBreaker opens after threshold consecutive blocked responses (429/403) and
+// stays open for cooldown before allowing traffic through again.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	failures  int
+	threshold int
+	cooldown  time.Duration
+	open      bool
+	openedAt  time.Time
+}
+
+// NewCircuitBreaker creates a breaker that trips after threshold consecutive
+// failures and resets after cooldown.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// RecordResult reports whether the last request was blocked (429/403).
+func (c *CircuitBreaker) RecordResult(blocked bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if blocked {
+		c.failures++
+		if c.failures >= c.threshold {
+			c.open = true
+			c.openedAt = time.Now()
+		}
+		return
+	}
+	c.failures = 0
+	c.open = false
+}
+
+// Open reports whether the breaker is currently tripped, clearing it once cooldown
+// has elapsed.
+func (c *CircuitBreaker) Open() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.open && time.Since(c.openedAt) > c.cooldown {
+		c.open = false
+		c.failures = 0
+	}
+	return c.open
+}
+
+// RemainingCooldown reports how much longer the breaker stays open, or zero if it's
+// already closed.
+func (c *CircuitBreaker) RemainingCooldown() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.open {
+		return 0
+	}
+	if remaining := c.cooldown - time.Since(c.openedAt); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// FetchGate bundles the rate limiter, politeness tracker, and circuit breaker that
+// every outgoing request must pass through.
+type FetchGate struct {
+	limiter    *RateLimiter
+	politeness *PolitenessTracker
+	breaker    *CircuitBreaker
+}
+
+// NewFetchGate wires up a gate from its configured knobs.
+func NewFetchGate(requestsPerSecond float64, politenessDelay time.Duration, breakerThreshold int, breakerCooldown time.Duration) *FetchGate {
+	return &FetchGate{
+		limiter:    NewRateLimiter(requestsPerSecond),
+		politeness: NewPolitenessTracker(politenessDelay),
+		breaker:    NewCircuitBreaker(breakerThreshold, breakerCooldown),
+	}
+}
+
+// Wait blocks for the rate limit and politeness delay, or returns an error wrapping
+// ErrCircuitOpen immediately if the circuit breaker (one breaker shared by every host)
+// is currently open.
+func (g *FetchGate) Wait(host string) error {
+	if g.breaker.Open() {
+		return fmt.Errorf("circuit breaker open for %s, backing off: %w", host, ErrCircuitOpen)
+	}
+	g.limiter.Wait()
+	g.politeness.Wait(host)
+	return nil
+}
+
+// CircuitCooldown reports how long callers should wait before trying the gate again
+// while the circuit breaker is open, so a requeued job doesn't busy-loop through the
+// gate until cooldown actually elapses.
+func (g *FetchGate) CircuitCooldown() time.Duration {
+	return g.breaker.RemainingCooldown()
+}
+
+// RecordStatus feeds an HTTP status code into the circuit breaker.
+func (g *FetchGate) RecordStatus(statusCode int) {
+	g.breaker.RecordResult(statusCode == 429 || statusCode == 403)
+}
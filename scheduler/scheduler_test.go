@@ -0,0 +1,92 @@
+package scheduler
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := OpenStore(filepath.Join(t.TempDir(), "jobs.db"))
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// TestQueueSeedAfterNewQueue guards against the closer goroutine racing Seed on a
+// fresh run: with no pending jobs the WaitGroup starts at 0, so Start must not be
+// called until after Seed, or Seed's send panics on a channel already closed by Wait
+// returning immediately.
+func TestQueueSeedAfterNewQueue(t *testing.T) {
+	queue, err := NewQueue(newTestStore(t))
+	if err != nil {
+		t.Fatalf("NewQueue: %v", err)
+	}
+
+	job := Job{Year: "2024", Month: "January", Page: 1}
+	if err := queue.Seed(job); err != nil {
+		t.Fatalf("Seed: %v", err)
+	}
+	queue.Start()
+
+	select {
+	case got, ok := <-queue.Jobs():
+		if !ok {
+			t.Fatal("Jobs channel closed before the seeded job was delivered")
+		}
+		if got != job {
+			t.Fatalf("got %v, want %v", got, job)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for seeded job")
+	}
+
+	if err := queue.Done(job, nil); err != nil {
+		t.Fatalf("Done: %v", err)
+	}
+
+	select {
+	case _, ok := <-queue.Jobs():
+		if ok {
+			t.Fatal("expected Jobs channel to close once the queue drained")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for Jobs channel to close")
+	}
+}
+
+// TestQueueRequeueReusesToken guards against Requeue leaking a WaitGroup token: since
+// the job pulled off the channel was never Done'd, Requeue must not Add(1) again, or
+// the count never reaches zero and the channel never closes.
+func TestQueueRequeueReusesToken(t *testing.T) {
+	queue, err := NewQueue(newTestStore(t))
+	if err != nil {
+		t.Fatalf("NewQueue: %v", err)
+	}
+
+	job := Job{Year: "2024", Month: "January", Page: 1}
+	if err := queue.Seed(job); err != nil {
+		t.Fatalf("Seed: %v", err)
+	}
+	queue.Start()
+
+	got := <-queue.Jobs()
+	queue.Requeue(got)
+
+	got = <-queue.Jobs()
+	if err := queue.Done(got, nil); err != nil {
+		t.Fatalf("Done: %v", err)
+	}
+
+	select {
+	case _, ok := <-queue.Jobs():
+		if ok {
+			t.Fatal("expected Jobs channel to close once the queue drained")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Requeue leaked a WaitGroup token: Jobs channel never closed")
+	}
+}
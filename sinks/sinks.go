@@ -0,0 +1,385 @@
+// Package sinks defines the OutputSink interface and the concrete destinations a
+// scrape run can be written to (CSV, NDJSON, SQLite, OSV-format JSON), plus a
+// Dispatcher that fans a single record stream out to however many sinks are active.
+package sinks
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/iseT1enLoc/ds108/cve"
+)
+
+// OutputSink persists scraped CVE records somewhere. Write is called once per record;
+// Close flushes and releases whatever resources the sink holds open.
+type OutputSink interface {
+	Write(record cve.CVE) error
+	Close() error
+}
+
+// csvHeader names the CSV sink's columns; the SQLite sink's cves table holds the same
+// fields, in the same order, under its own column names.
+var csvHeader = []string{"CVE ID", "CVE Type", "Description", "Max CVSS", "EPSS Score", "EPSS Percentile", "Published", "Updated", "CWE IDs", "CPEs", "References", "Known Exploited"}
+
+// flattenCWEIDs renders a record's CWE IDs as a single semicolon-separated column.
+func flattenCWEIDs(record cve.CVE) string {
+	return strings.Join(record.CWEIDs, ";")
+}
+
+// flattenCPEs renders each CPE match as "uri|start|end", joined by semicolons.
+func flattenCPEs(record cve.CVE) string {
+	parts := make([]string, len(record.CPEs))
+	for i, m := range record.CPEs {
+		parts[i] = strings.Join([]string{m.URI, m.VersionStartIncluding, m.VersionEndExcluding}, "|")
+	}
+	return strings.Join(parts, ";")
+}
+
+// flattenReferences renders each reference as "url|tag,tag", joined by semicolons.
+func flattenReferences(record cve.CVE) string {
+	parts := make([]string, len(record.References))
+	for i, r := range record.References {
+		parts[i] = r.URL + "|" + strings.Join(r.Tags, ",")
+	}
+	return strings.Join(parts, ";")
+}
+
+// CSVSink writes one CSV file for the run.
+type CSVSink struct {
+	file   *os.File
+	writer *csv.Writer
+}
+
+// NewCSVSink creates (or truncates) the CSV file at path and writes its header.
+func NewCSVSink(path string) (*CSVSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := csv.NewWriter(file)
+	if err := w.Write(csvHeader); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &CSVSink{file: file, writer: w}, nil
+}
+
+func (s *CSVSink) Write(record cve.CVE) error {
+	return s.writer.Write([]string{
+		record.ID, record.Type, record.Description, record.MaxCVSS, record.EPSSScore, record.EPSSPercentile, record.Published, record.Updated,
+		flattenCWEIDs(record), flattenCPEs(record), flattenReferences(record), strconv.FormatBool(record.KnownExploited),
+	})
+}
+
+func (s *CSVSink) Close() error {
+	s.writer.Flush()
+	if err := s.writer.Error(); err != nil {
+		s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
+
+// NDJSONSink writes one JSON object per line, which plays nicely with streaming
+// pipelines (jq, Kafka producers, etc.) that CSV and SQLite don't.
+type NDJSONSink struct {
+	file *os.File
+}
+
+// NewNDJSONSink creates (or truncates) the NDJSON file at path.
+func NewNDJSONSink(path string) (*NDJSONSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &NDJSONSink{file: file}, nil
+}
+
+func (s *NDJSONSink) Write(record cve.CVE) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = s.file.Write(append(data, '\n'))
+	return err
+}
+
+func (s *NDJSONSink) Close() error {
+	return s.file.Close()
+}
+
+// SQLiteSink upserts records into a single cve.db so repeated runs merge cleanly
+// instead of accumulating duplicate rows.
+type SQLiteSink struct {
+	db   *sql.DB
+	stmt *sql.Stmt
+}
+
+// NewSQLiteSink opens (or creates) the SQLite database at path and ensures the cves
+// table exists.
+func NewSQLiteSink(path string) (*SQLiteSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	const createTable = `
+CREATE TABLE IF NOT EXISTS cves (
+	id              TEXT PRIMARY KEY,
+	type            TEXT,
+	description     TEXT,
+	max_cvss        TEXT,
+	epss_score      TEXT,
+	epss_percentile TEXT,
+	published       TEXT,
+	updated         TEXT,
+	cwe_ids         TEXT,
+	cpes            TEXT,
+	refs            TEXT,
+	known_exploited INTEGER
+)`
+	if _, err := db.Exec(createTable); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	stmt, err := db.Prepare(`INSERT OR REPLACE INTO cves (id, type, description, max_cvss, epss_score, epss_percentile, published, updated, cwe_ids, cpes, refs, known_exploited) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteSink{db: db, stmt: stmt}, nil
+}
+
+func (s *SQLiteSink) Write(record cve.CVE) error {
+	_, err := s.stmt.Exec(
+		record.ID, record.Type, record.Description, record.MaxCVSS, record.EPSSScore, record.EPSSPercentile, record.Published, record.Updated,
+		flattenCWEIDs(record), flattenCPEs(record), flattenReferences(record), record.KnownExploited,
+	)
+	return err
+}
+
+func (s *SQLiteSink) Close() error {
+	s.stmt.Close()
+	return s.db.Close()
+}
+
+// osvSeverity mirrors the OSV schema's severity entry. We only have a bare CVSS base
+// score, not a full vector string, but OSV consumers tolerate the numeric form.
+type osvSeverity struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+type osvDatabaseSpecific struct {
+	EPSS           string `json:"epss,omitempty"`
+	EPSSPercentile string `json:"epss_percentile,omitempty"`
+	KnownExploited bool   `json:"known_exploited,omitempty"`
+}
+
+type osvEvent struct {
+	Introduced string `json:"introduced,omitempty"`
+	Fixed      string `json:"fixed,omitempty"`
+}
+
+type osvRange struct {
+	Type   string     `json:"type"`
+	Events []osvEvent `json:"events"`
+}
+
+type osvAffected struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	Ranges []osvRange `json:"ranges,omitempty"`
+}
+
+type osvReference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type osvEntry struct {
+	ID               string              `json:"id"`
+	Summary          string              `json:"summary,omitempty"`
+	Details          string              `json:"details,omitempty"`
+	Published        string              `json:"published,omitempty"`
+	Modified         string              `json:"modified,omitempty"`
+	Severity         []osvSeverity       `json:"severity,omitempty"`
+	Affected         []osvAffected       `json:"affected,omitempty"`
+	References       []osvReference      `json:"references,omitempty"`
+	DatabaseSpecific osvDatabaseSpecific `json:"database_specific,omitempty"`
+}
+
+// osvSummaryMaxLen caps the derived one-line summary OSV expects to be distinct from
+// (and shorter than) the full free-text details.
+const osvSummaryMaxLen = 120
+
+// osvSummary derives OSV's short one-line `summary` from a CVE's full description,
+// taking the first sentence (or truncating) since scraped records don't carry a
+// separate short-form summary of their own.
+func osvSummary(description string) string {
+	summary := description
+	if i := strings.IndexAny(summary, ".\n"); i != -1 {
+		summary = summary[:i]
+	}
+	summary = strings.TrimSpace(summary)
+	if len(summary) > osvSummaryMaxLen {
+		summary = strings.TrimSpace(summary[:osvSummaryMaxLen]) + "..."
+	}
+	return summary
+}
+
+// OSVSink writes one OSV-format JSON file per CVE, under storage/osv/<year>/<ID>.json.
+type OSVSink struct {
+	baseDir string
+}
+
+// NewOSVSink returns a sink that writes OSV entries under baseDir.
+func NewOSVSink(baseDir string) *OSVSink {
+	return &OSVSink{baseDir: baseDir}
+}
+
+func (s *OSVSink) Write(record cve.CVE) error {
+	year := "unknown"
+	if parts := strings.SplitN(record.ID, "-", 3); len(parts) == 3 && parts[0] == "CVE" {
+		year = parts[1]
+	}
+
+	entry := osvEntry{
+		ID:        record.ID,
+		Summary:   osvSummary(record.Description),
+		Details:   record.Description,
+		Published: record.Published,
+		Modified:  record.Updated,
+	}
+	if record.MaxCVSS != "" {
+		entry.Severity = []osvSeverity{{Type: "CVSS_V3", Score: record.MaxCVSS}}
+	}
+	if record.EPSSScore != "" {
+		entry.DatabaseSpecific.EPSS = record.EPSSScore
+	}
+	if record.EPSSPercentile != "" {
+		entry.DatabaseSpecific.EPSSPercentile = record.EPSSPercentile
+	}
+	entry.DatabaseSpecific.KnownExploited = record.KnownExploited
+
+	for _, m := range record.CPEs {
+		affected := osvAffected{Ranges: []osvRange{{
+			Type:   "ECOSYSTEM",
+			Events: []osvEvent{{Introduced: m.VersionStartIncluding, Fixed: m.VersionEndExcluding}},
+		}}}
+		affected.Package.Ecosystem = "CPE"
+		affected.Package.Name = m.URI
+		entry.Affected = append(entry.Affected, affected)
+	}
+
+	for _, r := range record.References {
+		refType := "WEB"
+		if len(r.Tags) > 0 {
+			refType = r.Tags[0]
+		}
+		entry.References = append(entry.References, osvReference{Type: refType, URL: r.URL})
+	}
+
+	dir := filepath.Join(s.baseDir, year)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, record.ID+".json"), data, 0644)
+}
+
+func (s *OSVSink) Close() error {
+	return nil
+}
+
+// Dispatcher fans a single record stream out to every active sink concurrently, each
+// over its own 100-record buffered channel, so a slow sink only stalls the others once
+// its buffer is full.
+type Dispatcher struct {
+	sinks []OutputSink
+	chans []chan cve.CVE
+	wg    sync.WaitGroup
+}
+
+// NewDispatcher starts one forwarding goroutine per sink.
+func NewDispatcher(sinks []OutputSink) *Dispatcher {
+	d := &Dispatcher{sinks: sinks}
+	for _, s := range sinks {
+		ch := make(chan cve.CVE, 100)
+		d.chans = append(d.chans, ch)
+		d.wg.Add(1)
+		go func(s OutputSink, ch chan cve.CVE) {
+			defer d.wg.Done()
+			for record := range ch {
+				if err := s.Write(record); err != nil {
+					log.Printf("Error writing record %s to sink %T: %v", record.ID, s, err)
+				}
+			}
+		}(s, ch)
+	}
+	return d
+}
+
+// Send delivers a record to every sink's channel.
+func (d *Dispatcher) Send(record cve.CVE) {
+	for _, ch := range d.chans {
+		ch <- record
+	}
+}
+
+// Close drains and closes every sink's channel, waits for the forwarders to finish,
+// then closes the sinks themselves.
+func (d *Dispatcher) Close() {
+	for _, ch := range d.chans {
+		close(ch)
+	}
+	d.wg.Wait()
+	for _, s := range d.sinks {
+		if err := s.Close(); err != nil {
+			log.Printf("Error closing sink %T: %v", s, err)
+		}
+	}
+}
+
+// New builds the OutputSink for a single --output name.
+func New(name, runTimestamp string) (OutputSink, error) {
+	switch name {
+	case "csv":
+		return NewCSVSink(filepath.Join("storage", fmt.Sprintf("CVE_%s.csv", runTimestamp)))
+	case "ndjson":
+		return NewNDJSONSink(filepath.Join("storage", fmt.Sprintf("CVE_%s.ndjson", runTimestamp)))
+	case "sqlite":
+		return NewSQLiteSink(filepath.Join("storage", "cve.db"))
+	case "osv":
+		return NewOSVSink(filepath.Join("storage", "osv")), nil
+	default:
+		return nil, fmt.Errorf("unknown output sink %q (want csv, ndjson, sqlite, or osv)", name)
+	}
+}